@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// configForProfile loads an aws.Config for a shared-config profile, the way
+// generateCredentialReport used to do it directly.
+func configForProfile(ctx context.Context, profile string) (aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithSharedConfigProfile(profile),
+		config.WithRegion("us-east-1"),
+	)
+
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("loading profile %q: %w", profile, err)
+	}
+
+	return cfg, nil
+}
+
+// resolveAccountIDs returns the accounts to scan in --assume-role mode: the
+// IDs listed in --accounts-file when one is given, otherwise the active
+// accounts organizations:ListAccounts returns for the base profile.
+func resolveAccountIDs(ctx context.Context, baseCfg aws.Config) ([]string, error) {
+	if runOpts.AccountsFile != "" {
+		accountIDs, err := readLines(runOpts.AccountsFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("accounts file: %w", err)
+		}
+
+		return accountIDs, nil
+	}
+
+	client := organizations.NewFromConfig(baseCfg)
+
+	var accountIDs []string
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("listing organization accounts: %w", err)
+		}
+
+		for _, account := range page.Accounts {
+			if account.Status != types.AccountStatusActive {
+				continue
+			}
+
+			accountIDs = append(accountIDs, aws.ToString(account.Id))
+		}
+	}
+
+	return accountIDs, nil
+}
+
+// assumeRoleConfig builds an aws.Config backed by temporary credentials for
+// the role --role-name-template resolves to for accountID.
+func assumeRoleConfig(ctx context.Context, baseCfg aws.Config, accountID string) (aws.Config, error) {
+	roleARN := strings.ReplaceAll(runOpts.RoleNameTemplate, "{account_id}", accountID)
+
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(baseCfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = runOpts.SessionName
+
+		if runOpts.ExternalID != "" {
+			o.ExternalID = aws.String(runOpts.ExternalID)
+		}
+	})
+
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(baseCfg.Region),
+		config.WithCredentialsProvider(aws.NewCredentialsCache(provider)),
+	)
+
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("assuming role %q: %w", roleARN, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// diffCommand is the "diff" subcommand: show what changed between the
+// latest run and the last run before --since.
+type diffCommand struct {
+	Since  string `long:"since" description:"How far back to compare against, e.g. 7d, 24h" default:"7d"`
+	Output string `short:"o" long:"output" description:"File to write the diff to, or - for stdout" default:"-"`
+	Format string `short:"f" long:"format" description:"Output format" default:"table" choice:"csv" choice:"json" choice:"yaml" choice:"table"`
+	Color  string `long:"color" description:"Color mode for table output" default:"auto" choice:"auto" choice:"yes" choice:"no"`
+}
+
+var diffOpts diffCommand
+
+func (c *diffCommand) Execute(args []string) error {
+	since, err := parseSince(c.Since)
+
+	if err != nil {
+		return err
+	}
+
+	db, err := openStore(globalOpts.Store)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	timestamps, err := runTimestamps(db)
+
+	if err != nil {
+		return err
+	}
+
+	if len(timestamps) == 0 {
+		return fmt.Errorf("no runs recorded yet; run `aws-credentials-reporter run` first")
+	}
+
+	latest := timestamps[0]
+	cutoff := latest.Add(-since)
+
+	var previous *time.Time
+	for _, ts := range timestamps[1:] {
+		if !ts.After(cutoff) {
+			ts := ts
+			previous = &ts
+			break
+		}
+	}
+
+	if previous == nil {
+		return fmt.Errorf("no run found before %s (--since %s)", cutoff.Format(time.RFC3339), c.Since)
+	}
+
+	newSnap, err := snapshotAt(db, latest)
+
+	if err != nil {
+		return err
+	}
+
+	oldSnap, err := snapshotAt(db, *previous)
+
+	if err != nil {
+		return err
+	}
+
+	kinds, removed := computeChanges(oldSnap.rows, newSnap.rows)
+	header, rows := diffRows(oldSnap, newSnap, kinds, removed)
+
+	output, err := newOutput(c.Format, c.Color)
+
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	return writeToDestination(c.Output, func(w io.Writer) error { return output.Write(w, header, rows) })
+}
+
+// trendCommand is the "trend" subcommand: show a single principal's history
+// across every stored run.
+type trendCommand struct {
+	User   string `long:"user" description:"Principal ARN to show the history of" required:"true"`
+	Output string `short:"o" long:"output" description:"File to write the trend to, or - for stdout" default:"-"`
+	Format string `short:"f" long:"format" description:"Output format" default:"table" choice:"csv" choice:"json" choice:"yaml" choice:"table"`
+	Color  string `long:"color" description:"Color mode for table output" default:"auto" choice:"auto" choice:"yes" choice:"no"`
+}
+
+var trendOpts trendCommand
+
+func (c *trendCommand) Execute(args []string) error {
+	db, err := openStore(globalOpts.Store)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	records, err := historyFor(db, c.User)
+
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return fmt.Errorf("no history found for %q", c.User)
+	}
+
+	header := append([]string{"run_timestamp"}, records[0].Header...)
+	rows := make([][]string, 0, len(records))
+
+	for _, rec := range records {
+		row := make([]string, 0, len(header))
+		row = append(row, rec.RunTimestamp.Format(time.RFC3339))
+
+		for _, col := range records[0].Header {
+			row = append(row, rec.Fields[col])
+		}
+
+		rows = append(rows, row)
+	}
+
+	output, err := newOutput(c.Format, c.Color)
+
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	return writeToDestination(c.Output, func(w io.Writer) error { return output.Write(w, header, rows) })
+}
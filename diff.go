@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rotationFields are compared between runs to decide whether an unchanged
+// principal counts as "rotated" or merely "stale".
+var rotationFields = [...]string{
+	"password_last_used",
+	"access_key_1_last_rotated",
+	"access_key_2_last_rotated",
+}
+
+// computeChanges compares two runs and classifies every principal present
+// in newRows as "new", "rotated" or "stale", and returns the keys present
+// in oldRows but missing from newRows ("removed").
+func computeChanges(oldRows, newRows map[recordKey]map[string]string) (kinds map[recordKey]string, removed []recordKey) {
+	kinds = make(map[recordKey]string, len(newRows))
+
+	for key, fields := range newRows {
+		old, existed := oldRows[key]
+
+		if !existed {
+			kinds[key] = "new"
+			continue
+		}
+
+		rotated := false
+		for _, col := range rotationFields {
+			if fields[col] != old[col] {
+				rotated = true
+				break
+			}
+		}
+
+		if rotated {
+			kinds[key] = "rotated"
+		} else {
+			kinds[key] = "stale"
+		}
+	}
+
+	for key := range oldRows {
+		if _, stillPresent := newRows[key]; !stillPresent {
+			removed = append(removed, key)
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].profile != removed[j].profile {
+			return removed[i].profile < removed[j].profile
+		}
+
+		return removed[i].arn < removed[j].arn
+	})
+
+	return kinds, removed
+}
+
+// parseSince parses a --since value such as "7d" or "24h" into a duration.
+// time.ParseDuration doesn't support day units, so "Nd" is handled directly.
+func parseSince(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid --since %q: %w", value, err)
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid --since %q: %w", value, err)
+	}
+
+	return d, nil
+}
+
+// diffRows builds the header/rows pair for a diff: the snapshot's columns
+// plus a trailing "change" column.
+func diffRows(oldSnap, newSnap *runSnapshot, kinds map[recordKey]string, removed []recordKey) ([]string, [][]string) {
+	cols := newSnap.header
+
+	if cols == nil {
+		cols = oldSnap.header
+	}
+
+	header := append(append([]string{}, cols...), "change")
+
+	keys := make([]recordKey, 0, len(newSnap.rows))
+	for key := range newSnap.rows {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].profile != keys[j].profile {
+			return keys[i].profile < keys[j].profile
+		}
+
+		return keys[i].arn < keys[j].arn
+	})
+
+	rows := make([][]string, 0, len(keys)+len(removed))
+
+	for _, key := range keys {
+		rows = append(rows, rowWithChange(cols, newSnap.rows[key], kinds[key]))
+	}
+
+	for _, key := range removed {
+		rows = append(rows, rowWithChange(cols, oldSnap.rows[key], "removed"))
+	}
+
+	return header, rows
+}
+
+func rowWithChange(cols []string, fields map[string]string, change string) []string {
+	row := make([]string, 0, len(cols)+1)
+
+	for _, col := range cols {
+		row = append(row, fields[col])
+	}
+
+	return append(row, change)
+}
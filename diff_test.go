@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeChanges(t *testing.T) {
+	oldRows := map[recordKey]map[string]string{
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/alice"}: {
+			"password_last_used":        "2026-01-01T00:00:00Z",
+			"access_key_1_last_rotated": "2026-01-01T00:00:00Z",
+		},
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/bob"}: {
+			"password_last_used":        "2026-01-01T00:00:00Z",
+			"access_key_1_last_rotated": "2026-01-01T00:00:00Z",
+		},
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/carol"}: {
+			"password_last_used":        "2026-01-01T00:00:00Z",
+			"access_key_1_last_rotated": "2026-01-01T00:00:00Z",
+		},
+	}
+
+	newRows := map[recordKey]map[string]string{
+		// alice: unchanged rotation fields -> stale
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/alice"}: {
+			"password_last_used":        "2026-01-01T00:00:00Z",
+			"access_key_1_last_rotated": "2026-01-01T00:00:00Z",
+		},
+		// bob: rotated an access key -> rotated
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/bob"}: {
+			"password_last_used":        "2026-01-01T00:00:00Z",
+			"access_key_1_last_rotated": "2026-02-01T00:00:00Z",
+		},
+		// dave: wasn't in the old run -> new
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/dave"}: {
+			"password_last_used":        "2026-02-15T00:00:00Z",
+			"access_key_1_last_rotated": "2026-02-15T00:00:00Z",
+		},
+		// carol is missing from newRows -> removed
+	}
+
+	kinds, removed := computeChanges(oldRows, newRows)
+
+	wantKinds := map[recordKey]string{
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/alice"}: "stale",
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/bob"}:   "rotated",
+		{profile: "prod", arn: "arn:aws:iam::111111111111:user/dave"}:  "new",
+	}
+
+	if len(kinds) != len(wantKinds) {
+		t.Fatalf("kinds = %v, want %v", kinds, wantKinds)
+	}
+
+	for key, want := range wantKinds {
+		if got := kinds[key]; got != want {
+			t.Errorf("kinds[%v] = %q, want %q", key, got, want)
+		}
+	}
+
+	wantRemoved := []recordKey{{profile: "prod", arn: "arn:aws:iam::111111111111:user/carol"}}
+
+	if len(removed) != len(wantRemoved) || removed[0] != wantRemoved[0] {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"24h", 24 * time.Hour, false},
+		{"30m", 30 * time.Minute, false},
+		{"not-a-duration", 0, true},
+		{"Nd", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSince(%q): expected an error, got nil", tt.value)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseSince(%q): %v", tt.value, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/jessevdk/go-flags"
 	"golang.org/x/sync/errgroup"
@@ -24,29 +25,53 @@ type profileResult struct {
 	rows    [][]string
 }
 
-var opts struct {
-	Profiles    []string `short:"p" long:"profile" description:"AWS profiles to include (can be repeated)" required:"true"`
-	Output      string   `short:"o" long:"output" description:"CSV file to save the credentials report to" default:"aws-credentials-report.csv"`
-	ExcludeRoot bool     `long:"exclude-root" description:"Exclude accounts root users from the credentials report"`
+// scanTarget is one unit of fan-out work: a label to tag its rows with (a
+// profile name or, in --assume-role mode, an account ID) and a way to build
+// the aws.Config to scan it with.
+type scanTarget struct {
+	idx   int
+	label string
+	cfg   func(ctx context.Context) (aws.Config, error)
 }
 
-func generateCredentialReport(ctx context.Context, profile string) ([]string, [][]string, error) {
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-		config.WithRegion("us-east-1"),
-	)
+// globalOpts holds flags shared by every subcommand.
+var globalOpts struct {
+	Store string `long:"store" description:"Path to the local BoltDB report store (default ~/.aws-credentials-reporter.db)"`
+}
 
-	if err != nil {
-		return nil, nil, fmt.Errorf("loading profile %q: %w", profile, err)
-	}
+// runCommand is the "run" subcommand: generate a credential report.
+type runCommand struct {
+	Profiles         []string `short:"p" long:"profile" description:"AWS profiles to include (can be repeated); auto-discovered from the shared config files when omitted"`
+	ProfilePattern   string   `long:"profile-pattern" description:"Only include discovered profiles matching this regex"`
+	ExcludeProfile   []string `long:"exclude-profile" description:"Discovered profile to exclude (can be repeated)"`
+	ProfilesFromFile string   `long:"profiles-from-file" description:"Path to a newline-delimited list of profiles to include"`
+	AssumeRole       bool     `long:"assume-role" description:"Fan out across every account in the AWS Organization by assuming --role-name-template from the single base --profile"`
+	RoleNameTemplate string   `long:"role-name-template" description:"Role ARN template for --assume-role, with {account_id} substituted, e.g. arn:aws:iam::{account_id}:role/Auditor"`
+	SessionName      string   `long:"session-name" description:"STS session name used by --assume-role" default:"aws-credentials-reporter"`
+	ExternalID       string   `long:"external-id" description:"STS external ID used by --assume-role"`
+	AccountsFile     string   `long:"accounts-file" description:"Newline-delimited list of account IDs, used instead of organizations:ListAccounts"`
+	Parallelism      int      `long:"parallelism" description:"Number of profiles/accounts to scan concurrently" default:"4"`
+	Output           string   `short:"o" long:"output" description:"File to save the credentials report to, or - for stdout" default:"aws-credentials-report.csv"`
+	Format           string   `short:"f" long:"format" description:"Output format" default:"csv" choice:"csv" choice:"json" choice:"yaml" choice:"table"`
+	Color            string   `long:"color" description:"Color mode for table output" default:"auto" choice:"auto" choice:"yes" choice:"no"`
+	ExcludeRoot      bool     `long:"exclude-root" description:"Exclude accounts root users from the credentials report"`
+	Policy           string   `long:"policy" description:"Path to a policy YAML file of IAM hygiene rules to evaluate the report against"`
+	FailOn           string   `long:"fail-on" description:"Exit non-zero when a finding at or above this severity is present" default:"high" choice:"info" choice:"low" choice:"medium" choice:"high" choice:"critical"`
+	FindingsOutput   string   `long:"findings-output" description:"Write a machine-readable findings report (JSON) to this path"`
+}
 
+var runOpts runCommand
+
+func generateCredentialReport(ctx context.Context, cfg aws.Config, label string) ([]string, [][]string, error) {
 	client := iam.NewFromConfig(cfg)
 
 	if _, err := client.GenerateCredentialReport(ctx, &iam.GenerateCredentialReportInput{}); err != nil {
-		return nil, nil, fmt.Errorf("generate credential report for %q: %w", profile, err)
+		return nil, nil, fmt.Errorf("generate credential report for %q: %w", label, err)
 	}
 
 	var report *iam.GetCredentialReportOutput
+	var err error
+
 	for i := 0; i < 10; i++ {
 		report, err = client.GetCredentialReport(ctx, &iam.GetCredentialReportInput{})
 
@@ -58,13 +83,13 @@ func generateCredentialReport(ctx context.Context, profile string) ([]string, []
 	}
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("fetch credential report for %q: %w", profile, err)
+		return nil, nil, fmt.Errorf("fetch credential report for %q: %w", label, err)
 	}
 
 	records, err := csv.NewReader(bytes.NewReader(report.Content)).ReadAll()
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("parse CSV for %q: %w", profile, err)
+		return nil, nil, fmt.Errorf("parse CSV for %q: %w", label, err)
 	}
 
 	if len(records) == 0 {
@@ -74,50 +99,119 @@ func generateCredentialReport(ctx context.Context, profile string) ([]string, []
 	return records[0], records[1:], nil
 }
 
-func main() {
-	if _, err := flags.Parse(&opts); err != nil {
-		os.Exit(1)
+// resolveTargets builds the scan targets for this run: one per profile, or
+// in --assume-role mode, one per account discovered in the organization.
+func resolveTargets(ctx context.Context) ([]scanTarget, error) {
+	if runOpts.Parallelism < 1 {
+		return nil, fmt.Errorf("--parallelism must be at least 1, got %d", runOpts.Parallelism)
+	}
+
+	if !runOpts.AssumeRole {
+		targets := make([]scanTarget, len(runOpts.Profiles))
+
+		for i, profile := range runOpts.Profiles {
+			profile := profile
+
+			targets[i] = scanTarget{
+				idx:   i,
+				label: profile,
+				cfg:   func(ctx context.Context) (aws.Config, error) { return configForProfile(ctx, profile) },
+			}
+		}
+
+		return targets, nil
+	}
+
+	if len(runOpts.Profiles) != 1 {
+		return nil, fmt.Errorf("--assume-role requires exactly one base --profile, got %d", len(runOpts.Profiles))
+	}
+
+	if runOpts.RoleNameTemplate == "" {
+		return nil, fmt.Errorf("--assume-role requires --role-name-template")
+	}
+
+	baseCfg, err := configForProfile(ctx, runOpts.Profiles[0])
+
+	if err != nil {
+		return nil, fmt.Errorf("loading base profile: %w", err)
+	}
+
+	accountIDs, err := resolveAccountIDs(ctx, baseCfg)
+
+	if err != nil {
+		return nil, fmt.Errorf("resolving accounts: %w", err)
 	}
 
-	// deduplicate profiles, preserving order
-	unique := make([]string, 0, len(opts.Profiles))
-	processed := make(map[string]struct{})
+	targets := make([]scanTarget, len(accountIDs))
+
+	for i, accountID := range accountIDs {
+		accountID := accountID
 
-	for _, profile := range opts.Profiles {
-		if _, exists := processed[profile]; !exists {
-			processed[profile] = struct{}{}
-			unique = append(unique, profile)
+		targets[i] = scanTarget{
+			idx:   i,
+			label: accountID,
+			cfg:   func(ctx context.Context) (aws.Config, error) { return assumeRoleConfig(ctx, baseCfg, accountID) },
 		}
 	}
 
-	opts.Profiles = unique
+	return targets, nil
+}
+
+// Execute fetches IAM credential reports for the resolved profiles/accounts,
+// writes them in the chosen format, optionally evaluates them against a
+// policy, and persists the run to the local store.
+func (c *runCommand) Execute(args []string) error {
+	profiles, err := resolveProfiles()
+
+	if err != nil {
+		return fmt.Errorf("resolving profiles: %w", err)
+	}
+
+	if len(profiles) == 0 {
+		return fmt.Errorf("no AWS profiles given or discovered; specify --profile or check ~/.aws/credentials and ~/.aws/config")
+	}
+
+	c.Profiles = profiles
 
 	ctx := context.Background()
+
+	targets, err := resolveTargets(ctx)
+
+	if err != nil {
+		return fmt.Errorf("resolving scan targets: %w", err)
+	}
+
 	var eg errgroup.Group
-	sem := make(chan struct{}, 4) // fetch 4 at once
-	results := make([]profileResult, len(opts.Profiles))
+	sem := make(chan struct{}, c.Parallelism)
+	results := make([]profileResult, len(targets))
 
-	for i, profile := range opts.Profiles {
-		i, profile := i, profile
+	for _, target := range targets {
+		target := target
 
 		eg.Go(func() error {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			header, rows, err := generateCredentialReport(ctx, profile)
+			cfg, err := target.cfg(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			header, rows, err := generateCredentialReport(ctx, cfg, target.label)
 
 			if err != nil {
 				return err
 			}
 
-			results[i] = profileResult{idx: i, profile: profile, header: header, rows: rows}
+			results[target.idx] = profileResult{idx: target.idx, profile: target.label, header: header, rows: rows}
 
 			return nil
 		})
 	}
 
 	if err := eg.Wait(); err != nil {
-		log.Fatalf("error fetching reports: %v", err)
+		return fmt.Errorf("fetching reports: %w", err)
 	}
 
 	// sort results
@@ -125,52 +219,134 @@ func main() {
 		return results[i].idx < results[j].idx
 	})
 
-	// look for the arn column for root user filtering
-	arnIdx := -1
+	var header []string
 	if len(results) > 0 {
-		for i, h := range results[0].header {
-			if h == "arn" {
-				arnIdx = i
-				break
-			}
+		header = append([]string{"profile"}, results[0].header...)
+	}
+
+	rows := make([][]string, 0, 1000)
+
+	for _, res := range results {
+		for _, row := range res.rows {
+			rows = append(rows, append([]string{res.profile}, row...))
 		}
+	}
+
+	output, err := newOutput(c.Format, c.Color)
+
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	// --exclude-root only affects what's rendered to the destination; the
+	// root principal still feeds policy evaluation and the historical store
+	// below, since "root has active access keys" is itself a hygiene check.
+	displayRows := rows
 
-		if arnIdx < 0 {
-			log.Fatalf("unable to locate arn column in report header")
+	if c.ExcludeRoot {
+		displayRows, err = excludeRoot(header, rows)
+
+		if err != nil {
+			return err
 		}
 	}
 
-	allResults := make([][]string, 0, 1000)
+	if err := writeToDestination(c.Output, func(w io.Writer) error { return output.Write(w, header, displayRows) }); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
 
-	if len(results) > 0 {
-		allResults = append(allResults, append([]string{"profile"}, results[0].header...))
+	if err := c.persist(header, rows); err != nil {
+		return fmt.Errorf("persisting run to store: %w", err)
 	}
 
-	for _, res := range results {
-		for _, row := range res.rows {
-			if opts.ExcludeRoot {
-				if strings.HasSuffix(row[arnIdx], ":root") {
-					continue
-				}
-			}
+	if c.Policy == "" {
+		return nil
+	}
+
+	p, err := loadPolicy(c.Policy)
 
-			allResults = append(allResults, append([]string{res.profile}, row...))
+	if err != nil {
+		return fmt.Errorf("loading policy: %w", err)
+	}
+
+	findings, err := evaluatePolicy(p, header, rows)
+
+	if err != nil {
+		return fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	printFindings(os.Stderr, findings)
+
+	if c.FindingsOutput != "" {
+		if err := writeFindingsOutput(c.FindingsOutput, findings); err != nil {
+			return fmt.Errorf("writing findings output: %w", err)
 		}
 	}
 
-	file, err := os.Create(opts.Output)
+	if failsPolicy(findings, c.FailOn) {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+func (c *runCommand) persist(header []string, rows [][]string) error {
+	db, err := openStore(globalOpts.Store)
 
 	if err != nil {
-		log.Fatalf("unable to create CSV %s: %v", opts.Output, err)
+		return err
 	}
 
-	defer file.Close()
+	defer db.Close()
+
+	return saveRun(db, header, rows, time.Now().UTC())
+}
 
-	writer := csv.NewWriter(file)
+// excludeRoot drops root-principal rows from rows for display purposes only;
+// callers that feed policy evaluation or the historical store must use the
+// unfiltered rows instead.
+func excludeRoot(header []string, rows [][]string) ([][]string, error) {
+	arnIdx := -1
+	for i, h := range header {
+		if h == "arn" {
+			arnIdx = i
+			break
+		}
+	}
+
+	if arnIdx < 0 {
+		return nil, fmt.Errorf("unable to locate arn column in report header")
+	}
+
+	filtered := make([][]string, 0, len(rows))
+
+	for _, row := range rows {
+		if strings.HasSuffix(row[arnIdx], ":root") {
+			continue
+		}
 
-	if err := writer.WriteAll(allResults); err != nil {
-		log.Fatalf("error writing CSV: %v", err)
+		filtered = append(filtered, row)
 	}
 
-	writer.Flush()
+	return filtered, nil
+}
+
+func main() {
+	parser := flags.NewParser(&globalOpts, flags.Default)
+
+	if _, err := parser.AddCommand("run", "Generate a credential report", "Fetches IAM credential reports for the given profiles/accounts and writes them in the chosen format.", &runOpts); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if _, err := parser.AddCommand("diff", "Show changes since a previous run", "Compares the latest stored run against the last run before --since and prints what changed.", &diffOpts); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if _, err := parser.AddCommand("trend", "Show a principal's history", "Prints the chronological view of a single principal across stored runs.", &trendOpts); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExcludeRoot(t *testing.T) {
+	header := []string{"profile", "arn", "password_enabled"}
+	rows := [][]string{
+		{"prod", "arn:aws:iam::111111111111:root", "true"},
+		{"prod", "arn:aws:iam::111111111111:user/alice", "true"},
+	}
+
+	filtered, err := excludeRoot(header, rows)
+
+	if err != nil {
+		t.Fatalf("excludeRoot: %v", err)
+	}
+
+	if len(filtered) != 1 || filtered[0][1] != "arn:aws:iam::111111111111:user/alice" {
+		t.Errorf("excludeRoot() = %v, want only the non-root row", filtered)
+	}
+}
+
+func TestExcludeRootMissingArnColumn(t *testing.T) {
+	header := []string{"profile", "password_enabled"}
+	rows := [][]string{{"prod", "true"}}
+
+	if _, err := excludeRoot(header, rows); err == nil {
+		t.Fatal("excludeRoot() with no arn column: expected an error, got nil")
+	}
+}
+
+func TestResolveTargetsRejectsInvalidParallelism(t *testing.T) {
+	defer func(saved runCommand) { runOpts = saved }(runOpts)
+
+	runOpts = runCommand{Profiles: []string{"default"}}
+
+	for _, parallelism := range []int{0, -1} {
+		runOpts.Parallelism = parallelism
+
+		if _, err := resolveTargets(context.Background()); err == nil {
+			t.Errorf("resolveTargets() with --parallelism %d: expected an error, got nil", parallelism)
+		}
+	}
+
+	runOpts.Parallelism = 4
+
+	if _, err := resolveTargets(context.Background()); err != nil {
+		t.Errorf("resolveTargets() with --parallelism 4: %v", err)
+	}
+}
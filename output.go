@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+)
+
+// Output writes a credential report, given its header row and data rows, to w.
+type Output interface {
+	Write(w io.Writer, header []string, rows [][]string) error
+}
+
+func newOutput(format string, colorMode string) (Output, error) {
+	switch format {
+	case "csv":
+		return csvOutput{}, nil
+	case "json":
+		return jsonOutput{}, nil
+	case "yaml":
+		return yamlOutput{}, nil
+	case "table":
+		return tableOutput{color: colorMode}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+type csvOutput struct{}
+
+func (csvOutput) Write(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+
+	all := make([][]string, 0, len(rows)+1)
+	all = append(all, header)
+	all = append(all, rows...)
+
+	if err := writer.WriteAll(all); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// userRecords converts the raw header/rows pair into a slice of per-user
+// objects keyed by column name, so JSON/YAML consumers get structured
+// objects instead of positional CSV rows.
+func userRecords(header []string, rows [][]string) []map[string]string {
+	records := make([]map[string]string, 0, len(rows))
+
+	for _, row := range rows {
+		records = append(records, rowToFields(header, row))
+	}
+
+	return records
+}
+
+type jsonOutput struct{}
+
+func (jsonOutput) Write(w io.Writer, header []string, rows [][]string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(userRecords(header, rows)); err != nil {
+		return fmt.Errorf("writing JSON: %w", err)
+	}
+
+	return nil
+}
+
+type yamlOutput struct{}
+
+func (yamlOutput) Write(w io.Writer, header []string, rows [][]string) error {
+	enc := yaml.NewEncoder(w)
+
+	if err := enc.Encode(userRecords(header, rows)); err != nil {
+		enc.Close()
+		return fmt.Errorf("writing YAML: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("writing YAML: %w", err)
+	}
+
+	return nil
+}
+
+// timestampColumns are credential-report fields whose RFC3339 values are
+// rendered as relative times ("3 days ago") in table mode; "N/A" and other
+// non-timestamp values are shown as they are.
+var timestampColumns = map[string]bool{
+	"password_last_used":          true,
+	"access_key_1_last_rotated":   true,
+	"access_key_1_last_used_date": true,
+	"access_key_2_last_rotated":   true,
+	"access_key_2_last_used_date": true,
+	"cert_1_last_rotated":         true,
+	"cert_2_last_rotated":         true,
+}
+
+func relativeTime(value string) string {
+	if value == "" || value == "N/A" || value == "not_supported" {
+		return value
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return value
+	}
+
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+	}
+}
+
+type tableOutput struct {
+	color string
+}
+
+func (o tableOutput) Write(w io.Writer, header []string, rows [][]string) error {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+
+	if o.enableColor(w) {
+		headerColors := make([]tablewriter.Colors, len(header))
+		for i := range headerColors {
+			headerColors[i] = tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiCyanColor}
+		}
+
+		table.SetHeaderColor(headerColors...)
+	}
+
+	for _, row := range rows {
+		rendered := make([]string, len(row))
+
+		for i, value := range row {
+			if i < len(header) && timestampColumns[header[i]] {
+				rendered[i] = relativeTime(value)
+			} else {
+				rendered[i] = value
+			}
+		}
+
+		table.Append(rendered)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+func (o tableOutput) enableColor(w io.Writer) bool {
+	switch o.color {
+	case "yes":
+		return true
+	case "no":
+		return false
+	default:
+		f, ok := w.(*os.File)
+
+		if !ok {
+			return false
+		}
+
+		fi, err := f.Stat()
+
+		if err != nil {
+			return false
+		}
+
+		return (fi.Mode() & os.ModeCharDevice) != 0
+	}
+}
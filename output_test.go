@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUserRecords(t *testing.T) {
+	header := []string{"profile", "arn"}
+	rows := [][]string{
+		{"prod", "arn:aws:iam::111111111111:user/alice"},
+		{"prod", "arn:aws:iam::111111111111:user/bob"},
+	}
+
+	records := userRecords(header, rows)
+
+	if len(records) != 2 {
+		t.Fatalf("userRecords() returned %d records, want 2", len(records))
+	}
+
+	if records[0]["profile"] != "prod" || records[0]["arn"] != "arn:aws:iam::111111111111:user/alice" {
+		t.Errorf("userRecords()[0] = %v, want profile=prod arn=.../alice", records[0])
+	}
+
+	if records[1]["arn"] != "arn:aws:iam::111111111111:user/bob" {
+		t.Errorf("userRecords()[1] = %v, want arn=.../bob", records[1])
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"empty value passes through", "", ""},
+		{"N/A passes through", "N/A", "N/A"},
+		{"not_supported passes through", "not_supported", "not_supported"},
+		{"unparseable value passes through", "garbage", "garbage"},
+		{"just now", time.Now().Add(-30 * time.Second).Format(time.RFC3339), "just now"},
+		{"minutes ago", time.Now().Add(-5 * time.Minute).Format(time.RFC3339), "5 minutes ago"},
+		{"hours ago", time.Now().Add(-3 * time.Hour).Format(time.RFC3339), "3 hours ago"},
+		{"days ago", time.Now().Add(-48 * time.Hour).Format(time.RFC3339), "2 days ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativeTime(tt.value); got != tt.want {
+				t.Errorf("relativeTime(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVOutputWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	header := []string{"profile", "arn"}
+	rows := [][]string{{"prod", "arn:aws:iam::111111111111:user/alice"}}
+
+	if err := (csvOutput{}).Write(&buf, header, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := "profile,arn\nprod,arn:aws:iam::111111111111:user/alice\n"
+
+	if buf.String() != want {
+		t.Errorf("Write() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJSONOutputWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	header := []string{"profile", "arn"}
+	rows := [][]string{{"prod", "arn:aws:iam::111111111111:user/alice"}}
+
+	if err := (jsonOutput{}).Write(&buf, header, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var got []map[string]string
+
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshaling output: %v", err)
+	}
+
+	if len(got) != 1 || got[0]["profile"] != "prod" {
+		t.Errorf("Write() produced %v, want one record with profile=prod", got)
+	}
+}
+
+func TestYAMLOutputWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	header := []string{"profile", "arn"}
+	rows := [][]string{{"prod", "arn:aws:iam::111111111111:user/alice"}}
+
+	if err := (yamlOutput{}).Write(&buf, header, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var got []map[string]string
+
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshaling output: %v", err)
+	}
+
+	if len(got) != 1 || got[0]["profile"] != "prod" {
+		t.Errorf("Write() produced %v, want one record with profile=prod", got)
+	}
+}
+
+func TestTableOutputWrite(t *testing.T) {
+	var buf bytes.Buffer
+
+	header := []string{"profile", "password_last_used"}
+	rows := [][]string{{"prod", time.Now().Add(-48 * time.Hour).Format(time.RFC3339)}}
+
+	if err := (tableOutput{color: "no"}).Write(&buf, header, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	out := buf.String()
+
+	if !strings.Contains(out, "2 days ago") {
+		t.Errorf("Write() = %q, want it to render the timestamp column as a relative time", out)
+	}
+}
+
+func TestTableOutputEnableColor(t *testing.T) {
+	var buf bytes.Buffer
+
+	if !(tableOutput{color: "yes"}).enableColor(&buf) {
+		t.Error(`enableColor() with color "yes" = false, want true`)
+	}
+
+	if (tableOutput{color: "no"}).enableColor(&buf) {
+		t.Error(`enableColor() with color "no" = true, want false`)
+	}
+
+	if (tableOutput{color: "auto"}).enableColor(&buf) {
+		t.Error(`enableColor() with color "auto" on a non-file writer = true, want false`)
+	}
+}
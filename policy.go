@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+)
+
+var severityRank = map[string]int{
+	"info":     0,
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// rule is a single declarative IAM hygiene check loaded from a policy file.
+type rule struct {
+	ID       string         `yaml:"id"`
+	Check    string         `yaml:"check"`
+	Severity string         `yaml:"severity"`
+	Message  string         `yaml:"message"`
+	Params   map[string]int `yaml:"params"`
+}
+
+type policy struct {
+	Rules []rule `yaml:"rules"`
+}
+
+// finding is a single rule match against a single credential-report row.
+type finding struct {
+	RuleID    string `json:"rule_id"`
+	Severity  string `json:"severity"`
+	Profile   string `json:"profile"`
+	Principal string `json:"principal"`
+	Message   string `json:"message"`
+}
+
+func loadPolicy(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("reading policy %q: %w", path, err)
+	}
+
+	var p policy
+
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing policy %q: %w", path, err)
+	}
+
+	for _, r := range p.Rules {
+		if _, ok := severityRank[r.Severity]; !ok {
+			return nil, fmt.Errorf("rule %q: unknown severity %q", r.ID, r.Severity)
+		}
+	}
+
+	return &p, nil
+}
+
+// evaluatePolicy runs every rule against every row and returns the resulting
+// findings. header/rows are the same "profile" column prepended report rows
+// used by the Output implementations.
+func evaluatePolicy(p *policy, header []string, rows [][]string) ([]finding, error) {
+	var findings []finding
+
+	for _, row := range rows {
+		record := rowToFields(header, row)
+
+		for _, r := range p.Rules {
+			matched, err := r.evaluate(record)
+
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.ID, err)
+			}
+
+			if !matched {
+				continue
+			}
+
+			findings = append(findings, finding{
+				RuleID:    r.ID,
+				Severity:  r.Severity,
+				Profile:   record["profile"],
+				Principal: record["arn"],
+				Message:   r.Message,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+func (r rule) evaluate(record map[string]string) (bool, error) {
+	switch r.Check {
+	case "password-no-mfa":
+		return record["password_enabled"] == "true" && record["mfa_active"] == "false", nil
+
+	case "access-key-stale-rotation":
+		maxAge := r.Params["max_age_days"]
+
+		for _, n := range [...]string{"1", "2"} {
+			if record["access_key_"+n+"_active"] != "true" {
+				continue
+			}
+
+			age, ok := ageInDays(record["access_key_"+n+"_last_rotated"])
+
+			if ok && age > maxAge {
+				return true, nil
+			}
+		}
+
+		return false, nil
+
+	case "password-stale":
+		if record["password_enabled"] != "true" {
+			return false, nil
+		}
+
+		lastUsed := record["password_last_used"]
+
+		if lastUsed == "" || lastUsed == "N/A" || lastUsed == "no_information" {
+			return true, nil
+		}
+
+		age, ok := ageInDays(lastUsed)
+
+		return ok && age > r.Params["max_age_days"], nil
+
+	case "root-active-keys":
+		if !strings.HasSuffix(record["arn"], ":root") {
+			return false, nil
+		}
+
+		return record["access_key_1_active"] == "true" || record["access_key_2_active"] == "true", nil
+
+	default:
+		return false, fmt.Errorf("unknown check %q", r.Check)
+	}
+}
+
+// ageInDays parses an RFC3339 credential-report timestamp and returns its
+// age in days. ok is false when value isn't a parseable timestamp.
+func ageInDays(value string) (age int, ok bool) {
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return int(time.Since(t).Hours() / 24), true
+}
+
+// failsPolicy reports whether any finding meets or exceeds the --fail-on
+// severity threshold.
+func failsPolicy(findings []finding, failOn string) bool {
+	for _, f := range findings {
+		if severityRank[f.Severity] >= severityRank[failOn] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func printFindings(w *os.File, findings []finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] > severityRank[findings[j].Severity]
+	})
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"profile", "severity", "rule", "principal", "message"})
+
+	for _, f := range findings {
+		table.Append([]string{f.Profile, f.Severity, f.RuleID, f.Principal, f.Message})
+	}
+
+	table.Render()
+}
+
+func writeFindingsOutput(path string, findings []finding) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return fmt.Errorf("creating findings output %q: %w", path, err)
+	}
+
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(findings); err != nil {
+		return fmt.Errorf("writing findings output %q: %w", path, err)
+	}
+
+	return nil
+}
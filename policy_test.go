@@ -0,0 +1,201 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func rfc3339DaysAgo(days int) string {
+	return time.Now().Add(-time.Duration(days) * 24 * time.Hour).Format(time.RFC3339)
+}
+
+func TestRuleEvaluatePasswordNoMFA(t *testing.T) {
+	r := rule{Check: "password-no-mfa"}
+
+	tests := []struct {
+		name   string
+		record map[string]string
+		want   bool
+	}{
+		{"password enabled without mfa", map[string]string{"password_enabled": "true", "mfa_active": "false"}, true},
+		{"password enabled with mfa", map[string]string{"password_enabled": "true", "mfa_active": "true"}, false},
+		{"no password", map[string]string{"password_enabled": "false", "mfa_active": "false"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.evaluate(tt.record)
+
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluateAccessKeyStaleRotation(t *testing.T) {
+	r := rule{Check: "access-key-stale-rotation", Params: map[string]int{"max_age_days": 90}}
+
+	tests := []struct {
+		name   string
+		record map[string]string
+		want   bool
+	}{
+		{
+			name: "just under the threshold is not stale",
+			record: map[string]string{
+				"access_key_1_active":       "true",
+				"access_key_1_last_rotated": rfc3339DaysAgo(89),
+				"access_key_2_active":       "false",
+			},
+			want: false,
+		},
+		{
+			name: "just over the threshold is stale",
+			record: map[string]string{
+				"access_key_1_active":       "true",
+				"access_key_1_last_rotated": rfc3339DaysAgo(91),
+				"access_key_2_active":       "false",
+			},
+			want: true,
+		},
+		{
+			name: "inactive key is ignored regardless of age",
+			record: map[string]string{
+				"access_key_1_active":       "false",
+				"access_key_1_last_rotated": rfc3339DaysAgo(365),
+				"access_key_2_active":       "false",
+			},
+			want: false,
+		},
+		{
+			name: "second key alone can trigger the rule",
+			record: map[string]string{
+				"access_key_1_active":       "false",
+				"access_key_2_active":       "true",
+				"access_key_2_last_rotated": rfc3339DaysAgo(120),
+			},
+			want: true,
+		},
+		{
+			name: "unparseable rotation timestamp is ignored",
+			record: map[string]string{
+				"access_key_1_active":       "true",
+				"access_key_1_last_rotated": "N/A",
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.evaluate(tt.record)
+
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluatePasswordStale(t *testing.T) {
+	r := rule{Check: "password-stale", Params: map[string]int{"max_age_days": 30}}
+
+	tests := []struct {
+		name   string
+		record map[string]string
+		want   bool
+	}{
+		{"no password enabled", map[string]string{"password_enabled": "false"}, false},
+		{"never used counts as stale", map[string]string{"password_enabled": "true", "password_last_used": "no_information"}, true},
+		{"N/A counts as stale", map[string]string{"password_enabled": "true", "password_last_used": "N/A"}, true},
+		{"recently used is not stale", map[string]string{"password_enabled": "true", "password_last_used": rfc3339DaysAgo(1)}, false},
+		{"stale beyond the threshold", map[string]string{"password_enabled": "true", "password_last_used": rfc3339DaysAgo(45)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.evaluate(tt.record)
+
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluateRootActiveKeys(t *testing.T) {
+	r := rule{Check: "root-active-keys"}
+
+	tests := []struct {
+		name   string
+		record map[string]string
+		want   bool
+	}{
+		{
+			name:   "root with an active key",
+			record: map[string]string{"arn": "arn:aws:iam::123456789012:root", "access_key_1_active": "true", "access_key_2_active": "false"},
+			want:   true,
+		},
+		{
+			name:   "root with no active keys",
+			record: map[string]string{"arn": "arn:aws:iam::123456789012:root", "access_key_1_active": "false", "access_key_2_active": "false"},
+			want:   false,
+		},
+		{
+			name:   "non-root user with an active key",
+			record: map[string]string{"arn": "arn:aws:iam::123456789012:user/deploy", "access_key_1_active": "true", "access_key_2_active": "false"},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.evaluate(tt.record)
+
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEvaluateUnknownCheck(t *testing.T) {
+	r := rule{Check: "does-not-exist"}
+
+	if _, err := r.evaluate(map[string]string{}); err == nil {
+		t.Fatal("evaluate() with an unknown check: expected an error, got nil")
+	}
+}
+
+func TestAgeInDays(t *testing.T) {
+	age, ok := ageInDays(rfc3339DaysAgo(10))
+
+	if !ok {
+		t.Fatal("ageInDays() on a valid RFC3339 timestamp: ok = false")
+	}
+
+	if age != 10 {
+		t.Errorf("ageInDays() = %d, want 10", age)
+	}
+
+	if _, ok := ageInDays("N/A"); ok {
+		t.Error("ageInDays(\"N/A\") = ok, want not ok")
+	}
+}
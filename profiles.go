@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// profileLoader discovers AWS profiles defined in the shared credentials and
+// config files, so the tool can be pointed at an entire organization without
+// listing every account on the command line.
+type profileLoader struct {
+	credentialsFile string
+	configFile      string
+}
+
+func newProfileLoader() profileLoader {
+	home, _ := os.UserHomeDir()
+
+	return profileLoader{
+		credentialsFile: sharedFileFromEnv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(home, ".aws", "credentials")),
+		configFile:      sharedFileFromEnv("AWS_CONFIG_FILE", filepath.Join(home, ".aws", "config")),
+	}
+}
+
+func sharedFileFromEnv(envVar, fallback string) string {
+	if v := os.Getenv(envVar); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+// discover enumerates every profile defined across the credentials and
+// config files, deduplicated and sorted by name.
+func (l profileLoader) discover() ([]string, error) {
+	var profiles []string
+
+	fromCredentials, err := profileNamesFromFile(l.credentialsFile, false)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", l.credentialsFile, err)
+	}
+
+	profiles = append(profiles, fromCredentials...)
+
+	fromConfig, err := profileNamesFromFile(l.configFile, true)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", l.configFile, err)
+	}
+
+	profiles = append(profiles, fromConfig...)
+
+	profiles = dedupeProfiles(profiles)
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// profileNamesFromFile returns the profile names declared in an ini-style
+// shared config/credentials file. In the config file, profiles other than
+// "default" are declared as "[profile name]" rather than "[name]".
+func profileNamesFromFile(path string, configStyle bool) ([]string, error) {
+	cfg, err := ini.Load(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, section := range cfg.Sections() {
+		name := section.Name()
+
+		if name == ini.DefaultSection {
+			continue
+		}
+
+		if configStyle {
+			name = strings.TrimPrefix(name, "profile ")
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// profilesFromListFile reads a newline-delimited list of profile names.
+func profilesFromListFile(path string) ([]string, error) {
+	profiles, err := readLines(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("profiles file: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// filterProfiles narrows a discovered profile list down to those matching
+// pattern (if set) and not present in exclude.
+func filterProfiles(profiles []string, pattern string, exclude []string) ([]string, error) {
+	var re *regexp.Regexp
+
+	if pattern != "" {
+		compiled, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid --profile-pattern: %w", err)
+		}
+
+		re = compiled
+	}
+
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, p := range exclude {
+		excluded[p] = struct{}{}
+	}
+
+	filtered := make([]string, 0, len(profiles))
+
+	for _, p := range profiles {
+		if _, skip := excluded[p]; skip {
+			continue
+		}
+
+		if re != nil && !re.MatchString(p) {
+			continue
+		}
+
+		filtered = append(filtered, p)
+	}
+
+	return filtered, nil
+}
+
+// dedupeProfiles removes duplicate profile names, preserving first-seen order.
+func dedupeProfiles(profiles []string) []string {
+	unique := make([]string, 0, len(profiles))
+	processed := make(map[string]struct{})
+
+	for _, profile := range profiles {
+		if _, exists := processed[profile]; !exists {
+			processed[profile] = struct{}{}
+			unique = append(unique, profile)
+		}
+	}
+
+	return unique
+}
+
+// resolveProfiles combines explicit --profile flags and --profiles-from-file
+// entries, falling back to auto-discovery from the shared config files when
+// neither is given.
+func resolveProfiles() ([]string, error) {
+	all := append([]string{}, runOpts.Profiles...)
+
+	if runOpts.ProfilesFromFile != "" {
+		fromFile, err := profilesFromListFile(runOpts.ProfilesFromFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, fromFile...)
+	}
+
+	if len(all) == 0 {
+		discovered, err := newProfileLoader().discover()
+
+		if err != nil {
+			return nil, fmt.Errorf("discovering profiles: %w", err)
+		}
+
+		discovered, err = filterProfiles(discovered, runOpts.ProfilePattern, runOpts.ExcludeProfile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = discovered
+	}
+
+	return dedupeProfiles(all), nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestProfileNamesFromFile(t *testing.T) {
+	// The unsectioned lines at the top land in ini's implicit DEFAULT
+	// section, which is skipped; an explicit "[default]" section is just a
+	// regularly named profile and is kept, matching how AWS treats it.
+	credentials := writeTempFile(t, "credentials", `
+region = us-east-1
+
+[default]
+aws_access_key_id = AKIA...
+
+[staging]
+aws_access_key_id = AKIA...
+`)
+
+	names, err := profileNamesFromFile(credentials, false)
+
+	if err != nil {
+		t.Fatalf("profileNamesFromFile: %v", err)
+	}
+
+	if want := []string{"default", "staging"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("profileNamesFromFile(credentials) = %v, want %v", names, want)
+	}
+
+	config := writeTempFile(t, "config", `
+[default]
+region = us-east-1
+
+[profile prod]
+region = us-east-1
+`)
+
+	names, err = profileNamesFromFile(config, true)
+
+	if err != nil {
+		t.Fatalf("profileNamesFromFile: %v", err)
+	}
+
+	if want := []string{"default", "prod"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("profileNamesFromFile(config) = %v, want %v", names, want)
+	}
+}
+
+func TestFilterProfiles(t *testing.T) {
+	profiles := []string{"prod", "prod-readonly", "staging", "dev"}
+
+	filtered, err := filterProfiles(profiles, `^prod`, []string{"prod-readonly"})
+
+	if err != nil {
+		t.Fatalf("filterProfiles: %v", err)
+	}
+
+	if want := []string{"prod"}; !reflect.DeepEqual(filtered, want) {
+		t.Errorf("filterProfiles() = %v, want %v", filtered, want)
+	}
+
+	filtered, err = filterProfiles(profiles, "", nil)
+
+	if err != nil {
+		t.Fatalf("filterProfiles: %v", err)
+	}
+
+	if !reflect.DeepEqual(filtered, profiles) {
+		t.Errorf("filterProfiles() with no pattern/exclude = %v, want %v", filtered, profiles)
+	}
+
+	if _, err := filterProfiles(profiles, "(", nil); err == nil {
+		t.Error("filterProfiles() with an invalid pattern: expected an error, got nil")
+	}
+}
+
+func TestDedupeProfiles(t *testing.T) {
+	got := dedupeProfiles([]string{"prod", "staging", "prod", "dev", "staging"})
+
+	if want := []string{"prod", "staging", "dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeProfiles() = %v, want %v", got, want)
+	}
+}
+
+func withRunOpts(t *testing.T, opts runCommand) {
+	t.Helper()
+
+	saved := runOpts
+	runOpts = opts
+
+	t.Cleanup(func() { runOpts = saved })
+}
+
+func TestResolveProfilesExplicit(t *testing.T) {
+	withRunOpts(t, runCommand{Profiles: []string{"staging", "prod", "staging"}})
+
+	got, err := resolveProfiles()
+
+	if err != nil {
+		t.Fatalf("resolveProfiles: %v", err)
+	}
+
+	if want := []string{"staging", "prod"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveProfiles() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveProfilesFromFile(t *testing.T) {
+	listFile := writeTempFile(t, "profiles.txt", "staging\nprod\n# a comment\n\ndev\n")
+
+	withRunOpts(t, runCommand{Profiles: []string{"staging"}, ProfilesFromFile: listFile})
+
+	got, err := resolveProfiles()
+
+	if err != nil {
+		t.Fatalf("resolveProfiles: %v", err)
+	}
+
+	if want := []string{"staging", "prod", "dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveProfiles() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveProfilesDiscoversAndFilters(t *testing.T) {
+	credentials := writeTempFile(t, "credentials", `
+[prod]
+aws_access_key_id = AKIA...
+
+[staging]
+aws_access_key_id = AKIA...
+`)
+
+	for env, value := range map[string]string{
+		"AWS_SHARED_CREDENTIALS_FILE": credentials,
+		"AWS_CONFIG_FILE":             writeTempFile(t, "config", "[default]\nregion = us-east-1\n"),
+	} {
+		old, had := os.LookupEnv(env)
+		os.Setenv(env, value)
+
+		t.Cleanup(func(env string, old string, had bool) func() {
+			return func() {
+				if had {
+					os.Setenv(env, old)
+				} else {
+					os.Unsetenv(env)
+				}
+			}
+		}(env, old, had))
+	}
+
+	withRunOpts(t, runCommand{ExcludeProfile: []string{"staging"}, ProfilePattern: "^prod$"})
+
+	got, err := resolveProfiles()
+
+	if err != nil {
+		t.Fatalf("resolveProfiles: %v", err)
+	}
+
+	if want := []string{"prod"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveProfiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/asdine/storm/v3"
+)
+
+// storedRecord is one credential-report row as persisted for a single run,
+// keyed by (profile, user ARN, run timestamp). Header is duplicated onto
+// every record so a run's column order can be recovered without a separate
+// per-run table.
+type storedRecord struct {
+	ID           int       `storm:"id,increment"`
+	Profile      string    `storm:"index"`
+	UserARN      string    `storm:"index"`
+	RunTimestamp time.Time `storm:"index"`
+	Header       []string
+	Fields       map[string]string
+}
+
+// recordKey identifies a principal within a single run.
+type recordKey struct {
+	profile string
+	arn     string
+}
+
+func defaultStorePath() string {
+	home, _ := os.UserHomeDir()
+
+	return filepath.Join(home, ".aws-credentials-reporter.db")
+}
+
+func openStore(path string) (*storm.DB, error) {
+	if path == "" {
+		path = defaultStorePath()
+	}
+
+	db, err := storm.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("opening store %q: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// saveRun persists every row of a report under a single run timestamp.
+func saveRun(db *storm.DB, header []string, rows [][]string, runAt time.Time) error {
+	for _, row := range rows {
+		fields := rowToFields(header, row)
+
+		rec := storedRecord{
+			Profile:      fields["profile"],
+			UserARN:      fields["arn"],
+			RunTimestamp: runAt,
+			Header:       header,
+			Fields:       fields,
+		}
+
+		if err := db.Save(&rec); err != nil {
+			return fmt.Errorf("saving record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runTimestamps returns every distinct run timestamp recorded in the store,
+// most recent first.
+func runTimestamps(db *storm.DB) ([]time.Time, error) {
+	var records []storedRecord
+
+	if err := db.All(&records); err != nil {
+		return nil, fmt.Errorf("reading store: %w", err)
+	}
+
+	seen := make(map[time.Time]struct{})
+	var timestamps []time.Time
+
+	for _, r := range records {
+		if _, ok := seen[r.RunTimestamp]; ok {
+			continue
+		}
+
+		seen[r.RunTimestamp] = struct{}{}
+		timestamps = append(timestamps, r.RunTimestamp)
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].After(timestamps[j]) })
+
+	return timestamps, nil
+}
+
+// runSnapshot is every row recorded for a single run, indexed by
+// (profile, user ARN), alongside the column order those rows were in.
+type runSnapshot struct {
+	header []string
+	rows   map[recordKey]map[string]string
+}
+
+// snapshotAt returns the snapshot saved at exactly runAt.
+func snapshotAt(db *storm.DB, runAt time.Time) (*runSnapshot, error) {
+	var records []storedRecord
+
+	if err := db.Find("RunTimestamp", runAt, &records); err != nil {
+		return nil, fmt.Errorf("querying store: %w", err)
+	}
+
+	snap := &runSnapshot{rows: make(map[recordKey]map[string]string, len(records))}
+
+	for _, r := range records {
+		if snap.header == nil {
+			snap.header = r.Header
+		}
+
+		snap.rows[recordKey{profile: r.Profile, arn: r.UserARN}] = r.Fields
+	}
+
+	return snap, nil
+}
+
+// historyFor returns every stored record for a single principal ARN, oldest
+// first.
+func historyFor(db *storm.DB, arn string) ([]storedRecord, error) {
+	var records []storedRecord
+
+	if err := db.Find("UserARN", arn, &records); err != nil {
+		return nil, fmt.Errorf("querying store: %w", err)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].RunTimestamp.Before(records[j].RunTimestamp) })
+
+	return records, nil
+}
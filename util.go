@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readLines reads a newline-delimited list of values from path, skipping
+// blank lines and "#" comments.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %w", path, err)
+	}
+
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %q: %w", path, err)
+	}
+
+	return lines, nil
+}
+
+// rowToFields zips a report header with one of its rows into a column-name
+// keyed map.
+func rowToFields(header []string, row []string) map[string]string {
+	fields := make(map[string]string, len(header))
+
+	for i, col := range header {
+		if i < len(row) {
+			fields[col] = row[i]
+		}
+	}
+
+	return fields
+}
+
+// writeToDestination calls write with stdout, or a newly created file at
+// path when path isn't "-" or empty.
+func writeToDestination(path string, write func(io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+
+	defer file.Close()
+
+	return write(file)
+}